@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/howeyc/gopass"
+
+	"github.com/malcolmseyd/mc-rcon/rcon"
+)
+
+// readPassword returns the RCON password, preferring MCRCON_PASSWORD (so
+// scripted/non-interactive runs don't need a terminal) and falling back to
+// an interactive prompt.
+func readPassword() (string, error) {
+	if password := os.Getenv("MCRCON_PASSWORD"); password != "" {
+		return password, nil
+	}
+	if !isTerminal(os.Stdin) {
+		return "", fmt.Errorf("MCRCON_PASSWORD is not set and stdin is not a terminal")
+	}
+
+	bytePassword, err := gopass.GetPasswdPrompt("Password: ", false, os.Stdin, os.Stdout)
+	if err != nil {
+		return "", err
+	}
+	return string(bytePassword), nil
+}
+
+// commandList collects repeated -c flags in the order they were given.
+type commandList []string
+
+func (c *commandList) String() string {
+	return strings.Join(*c, ", ")
+}
+
+func (c *commandList) Set(value string) error {
+	*c = append(*c, value)
+	return nil
+}
+
+// commandResult is the structured record emitted per command in json/ndjson
+// output mode.
+type commandResult struct {
+	Command    string `json:"command"`
+	Response   string `json:"response"`
+	DurationMs int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+// isTerminal reports whether f is attached to an interactive terminal.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+// readCommands scans newline-delimited commands from r, skipping blank
+// lines.
+func readCommands(r io.Reader) ([]string, error) {
+	var commands []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		commands = append(commands, line)
+	}
+	return commands, scanner.Err()
+}
+
+// readScriptFile reads newline-delimited commands from the file at path.
+func readScriptFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readCommands(f)
+}
+
+// isServerError reports whether response looks like a vanilla Minecraft
+// command error rather than successful output. There's no structured error
+// channel in the RCON protocol itself, so this is necessarily a heuristic
+// over the server's well-known error message prefixes.
+func isServerError(response string) bool {
+	errorPrefixes := []string{
+		"Unknown command",
+		"Unknown or incomplete command",
+		"Incorrect argument for command",
+		"That position is not loaded",
+	}
+	for _, prefix := range errorPrefixes {
+		if strings.HasPrefix(response, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// runBatch executes commands non-interactively against client, writing
+// results in the requested output format. It returns the process exit code:
+// nonzero if any command produced a transport error or a server error
+// response.
+func runBatch(ctx context.Context, client *rcon.Client, commands []string, output string) int {
+	exitCode := 0
+	results := make([]commandResult, 0, len(commands))
+
+	for _, command := range commands {
+		start := time.Now()
+		execCtx, cancel := context.WithTimeout(ctx, defaultTimeout)
+		response, err := client.Exec(execCtx, command)
+		cancel()
+
+		result := commandResult{
+			Command:    command,
+			Response:   response,
+			DurationMs: time.Since(start).Milliseconds(),
+		}
+		if err != nil {
+			result.Error = err.Error()
+			exitCode = 1
+		} else if isServerError(response) {
+			exitCode = 1
+		}
+
+		switch output {
+		case "ndjson":
+			printJSONLine(result)
+		case "json":
+			results = append(results, result)
+		default:
+			if err != nil {
+				errorln(command+":", err)
+			} else {
+				fmt.Println(response)
+			}
+		}
+	}
+
+	if output == "json" {
+		printJSONLine(results)
+	}
+	return exitCode
+}
+
+func printJSONLine(v interface{}) {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		errorln("failed to encode output:", err)
+		return
+	}
+	fmt.Println(string(encoded))
+}
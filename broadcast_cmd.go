@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/malcolmseyd/mc-rcon/rcon"
+)
+
+// serverEntry is one server listed in a --servers YAML file.
+type serverEntry struct {
+	Name     string `yaml:"name"`
+	Addr     string `yaml:"addr"`
+	Password string `yaml:"password"`
+}
+
+// hostList collects repeated -H host:port flags in the order they were
+// given.
+type hostList []string
+
+func (h *hostList) String() string {
+	return strings.Join(*h, ", ")
+}
+
+func (h *hostList) Set(value string) error {
+	*h = append(*h, value)
+	return nil
+}
+
+// runBroadcastCmd implements the `mc-rcon broadcast` subcommand: run one
+// command against many servers concurrently and report how each one did.
+func runBroadcastCmd(args []string) {
+	fs := flag.NewFlagSet("broadcast", flag.ExitOnError)
+
+	var command string
+	var serversFile string
+	var hosts hostList
+	var output string
+	var concurrency int
+	var timeout time.Duration
+	var retries int
+
+	fs.StringVar(&command, "c", "", "command to run against every server")
+	fs.StringVar(&serversFile, "servers", "", "YAML file listing servers to broadcast to")
+	fs.Var(&hosts, "H", "host:port to broadcast to, using the shared password (repeatable)")
+	fs.StringVar(&output, "output", "text", "output format: text, json")
+	fs.IntVar(&concurrency, "concurrency", 0, "maximum servers to contact at once (default: all)")
+	fs.DurationVar(&timeout, "timeout", defaultTimeout, "timeout per dial/login/command attempt")
+	fs.IntVar(&retries, "retries", 2, "retries per server on failure, with exponential backoff")
+
+	fs.Parse(args)
+
+	if command == "" {
+		ferrorln("broadcast: -c is required")
+	}
+	if output != "text" && output != "json" {
+		ferrorln("broadcast: invalid --output:", output)
+	}
+
+	targets, err := loadBroadcastTargets(serversFile, hosts)
+	if err != nil {
+		ferrorln(err)
+	}
+	if len(targets) == 0 {
+		ferrorln("broadcast: no servers given; use --servers or -H")
+	}
+
+	results := rcon.Broadcast(context.Background(), targets, command, rcon.BroadcastOptions{
+		Concurrency: concurrency,
+		Timeout:     timeout,
+		Retries:     retries,
+	})
+
+	exitCode := 0
+	for _, result := range results {
+		if result.Error != "" {
+			exitCode = 1
+		}
+	}
+
+	switch output {
+	case "json":
+		printJSONLine(results)
+	default:
+		printBroadcastTable(results)
+	}
+	os.Exit(exitCode)
+}
+
+// loadBroadcastTargets builds the target list from --servers and/or
+// repeated -H flags, reading the RCON password once for the -H targets.
+func loadBroadcastTargets(serversFile string, hosts hostList) ([]rcon.Target, error) {
+	var targets []rcon.Target
+
+	if serversFile != "" {
+		entries, err := loadServersFile(serversFile)
+		if err != nil {
+			return nil, fmt.Errorf("--servers: %w", err)
+		}
+		for _, entry := range entries {
+			targets = append(targets, rcon.Target{
+				Name:     entry.Name,
+				Addr:     entry.Addr,
+				Password: entry.Password,
+			})
+		}
+	}
+
+	if len(hosts) > 0 {
+		password, err := readPassword()
+		if err != nil {
+			return nil, err
+		}
+		for _, addr := range hosts {
+			if _, _, err := net.SplitHostPort(addr); err != nil {
+				addr = net.JoinHostPort(addr, "25575")
+			}
+			targets = append(targets, rcon.Target{
+				Addr:     addr,
+				Password: password,
+			})
+		}
+	}
+
+	return targets, nil
+}
+
+// loadServersFile parses a YAML file listing servers, e.g.:
+//
+//   - name: survival
+//     addr: survival.example.com:25575
+//     password: hunter2
+func loadServersFile(path string) ([]serverEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []serverEntry
+	if err := yaml.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// printBroadcastTable renders results as an aligned text table.
+func printBroadcastTable(results []rcon.BroadcastResult) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SERVER\tATTEMPTS\tRESULT")
+	for _, result := range results {
+		status := strings.ReplaceAll(result.Response, "\n", " ")
+		if result.Error != "" {
+			status = "error: " + result.Error
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\n", result.Target, result.Attempts, status)
+	}
+	w.Flush()
+}
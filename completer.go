@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"strings"
+)
+
+// shellCompleter drives readline's tab-completion from commandDiscovery:
+// the first word of a line completes against known server commands, and
+// later words complete against that command's own argument shape, as
+// reported by its `help <command>` usage (falling back to online player
+// names for arguments that look like they name a target).
+type shellCompleter struct {
+	discovery *commandDiscovery
+}
+
+func (c *shellCompleter) Do(line []rune, pos int) (newLine [][]rune, length int) {
+	text := string(line[:pos])
+	space := strings.LastIndexByte(text, ' ')
+	word := text[space+1:]
+
+	var candidates []string
+	if space == -1 {
+		candidates = c.discovery.Commands()
+	} else if fields := strings.Fields(text); len(fields) > 0 {
+		argIndex := len(fields) - 1
+		if word != "" {
+			argIndex--
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+		candidates = c.discovery.ArgCandidates(ctx, fields[0], argIndex)
+		cancel()
+	}
+
+	var matches [][]rune
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, word) {
+			matches = append(matches, []rune(candidate[len(word):]))
+		}
+	}
+	return matches, len(word)
+}
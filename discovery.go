@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/malcolmseyd/mc-rcon/rcon"
+)
+
+// playerListRefreshInterval is how often the online player cache used for
+// tab-completion is refreshed in the background.
+const playerListRefreshInterval = 10 * time.Second
+
+// commandDiscovery learns what a connected server's shell looks like -
+// its commands (from `help`) and its online players (from `list`, kept
+// fresh on a timer) - for the interactive shell's tab-completion. It also
+// serializes every command sent over client, since rcon.Client itself isn't
+// safe for concurrent use and the player-list refresh runs in the
+// background alongside whatever the user is typing.
+type commandDiscovery struct {
+	execMu sync.Mutex
+	client *rcon.Client
+
+	mu       sync.Mutex
+	commands []string
+	players  []string
+	argHelp  map[string]string
+}
+
+func newCommandDiscovery(client *rcon.Client) *commandDiscovery {
+	return &commandDiscovery{client: client}
+}
+
+// SetClient swaps in a new underlying connection, e.g. after /reconnect.
+func (d *commandDiscovery) SetClient(client *rcon.Client) {
+	d.execMu.Lock()
+	defer d.execMu.Unlock()
+	d.client = client
+}
+
+// Exec runs command against the current client. All command execution,
+// including the background refreshes below, goes through this method so
+// that only one request is ever in flight at a time.
+func (d *commandDiscovery) Exec(ctx context.Context, command string) (string, error) {
+	d.execMu.Lock()
+	defer d.execMu.Unlock()
+	return d.client.Exec(ctx, command)
+}
+
+// RefreshCommands re-runs `help` and updates the cached command list.
+func (d *commandDiscovery) RefreshCommands(ctx context.Context) {
+	execCtx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	response, err := d.Exec(execCtx, "help")
+	cancel()
+	if err != nil {
+		return
+	}
+
+	d.mu.Lock()
+	d.commands = parseHelpCommands(response)
+	d.mu.Unlock()
+}
+
+func (d *commandDiscovery) refreshPlayers(ctx context.Context) {
+	execCtx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	response, err := d.Exec(execCtx, "list")
+	cancel()
+	if err != nil {
+		return
+	}
+
+	d.mu.Lock()
+	d.players = parsePlayerList(response)
+	d.mu.Unlock()
+}
+
+// Run refreshes the player cache on a timer until ctx is canceled. Callers
+// should run it in its own goroutine.
+func (d *commandDiscovery) Run(ctx context.Context) {
+	ticker := time.NewTicker(playerListRefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.refreshPlayers(ctx)
+		}
+	}
+}
+
+func (d *commandDiscovery) Commands() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string(nil), d.commands...)
+}
+
+func (d *commandDiscovery) Players() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string(nil), d.players...)
+}
+
+// ArgCandidates returns tab-completion candidates for the argIndex'th
+// argument (0-based) of command. The command's own usage, learned lazily
+// via `help <command>` and cached thereafter, tells us what that argument
+// looks like: a literal set of keywords (e.g. a gamemode name) completes
+// to those keywords, while a player-shaped argument (e.g. <target>) falls
+// back to the cached online player list.
+func (d *commandDiscovery) ArgCandidates(ctx context.Context, command string, argIndex int) []string {
+	token := parseHelpUsageToken(command, d.helpFor(ctx, command), argIndex)
+	if token == "" {
+		return nil
+	}
+	if literals := parseLiteralAlternatives(token); literals != nil {
+		return literals
+	}
+	if looksLikePlayerArg(token) {
+		return d.Players()
+	}
+	return nil
+}
+
+// helpFor returns the `help <command>` text for command, fetching and
+// caching it on first use.
+func (d *commandDiscovery) helpFor(ctx context.Context, command string) string {
+	d.mu.Lock()
+	help, ok := d.argHelp[command]
+	d.mu.Unlock()
+	if ok {
+		return help
+	}
+
+	execCtx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	response, err := d.Exec(execCtx, "help "+command)
+	cancel()
+	if err != nil {
+		return ""
+	}
+
+	d.mu.Lock()
+	if d.argHelp == nil {
+		d.argHelp = make(map[string]string)
+	}
+	d.argHelp[command] = response
+	d.mu.Unlock()
+	return response
+}
+
+// parseHelpCommands extracts command names from vanilla `help`'s output,
+// one "/command <args...>" entry per line.
+func parseHelpCommands(helpOutput string) []string {
+	seen := make(map[string]bool)
+	var commands []string
+	for _, line := range strings.Split(helpOutput, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "/") {
+			continue
+		}
+		line = line[1:]
+		name := line
+		if end := strings.IndexAny(line, " \t"); end != -1 {
+			name = line[:end]
+		}
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		commands = append(commands, name)
+	}
+	sort.Strings(commands)
+	return commands
+}
+
+// parseHelpUsageToken extracts the argIndex'th argument token (0-based,
+// counting after the command name) from the first "/command ..." usage
+// line in a `help <command>` response.
+func parseHelpUsageToken(command, helpOutput string, argIndex int) string {
+	prefix := "/" + command
+	for _, line := range strings.Split(helpOutput, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, prefix) {
+			continue
+		}
+		tokens := strings.Fields(strings.TrimSpace(line[len(prefix):]))
+		if argIndex < len(tokens) {
+			return tokens[argIndex]
+		}
+		return ""
+	}
+	return ""
+}
+
+// parseLiteralAlternatives reports whether token is a literal keyword
+// choice, e.g. "(survival|creative|adventure|spectator)", and if so
+// returns the choices. It returns nil for placeholder tokens like
+// "<target>" that don't enumerate their values.
+func parseLiteralAlternatives(token string) []string {
+	token = strings.Trim(token, "[]")
+	if !strings.HasPrefix(token, "(") || !strings.HasSuffix(token, ")") {
+		return nil
+	}
+	token = strings.Trim(token, "()")
+	if !strings.Contains(token, "|") {
+		return nil
+	}
+	return strings.Split(token, "|")
+}
+
+// looksLikePlayerArg reports whether token is a placeholder that names a
+// player, e.g. "<target>" or "[<player>]".
+func looksLikePlayerArg(token string) bool {
+	token = strings.ToLower(token)
+	return strings.Contains(token, "target") || strings.Contains(token, "player") || strings.Contains(token, "victim")
+}
+
+// parsePlayerList extracts player names from vanilla `list`'s output, e.g.
+// "There are 2 of a max of 20 players online: Alice, Bob".
+func parsePlayerList(listOutput string) []string {
+	idx := strings.LastIndex(listOutput, ":")
+	if idx == -1 {
+		return nil
+	}
+
+	var players []string
+	for _, name := range strings.Split(listOutput[idx+1:], ",") {
+		name = strings.TrimSpace(name)
+		if name != "" {
+			players = append(players, name)
+		}
+	}
+	return players
+}
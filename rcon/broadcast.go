@@ -0,0 +1,135 @@
+package rcon
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Target identifies one server to broadcast a command to.
+type Target struct {
+	// Name labels this target in BroadcastResult; defaults to Addr.
+	Name     string
+	Addr     string
+	Password string
+	// Dialer defaults to TCPDialer{} if nil.
+	Dialer Dialer
+}
+
+// BroadcastOptions configures Broadcast's concurrency and retry behavior.
+type BroadcastOptions struct {
+	// Concurrency bounds how many targets are dialed at once. Defaults to
+	// len(targets) if zero or negative.
+	Concurrency int
+	// Timeout bounds each step (dial, authenticate, exec) of an attempt
+	// against a target separately. Defaults to 5 seconds if zero or
+	// negative.
+	Timeout time.Duration
+	// Retries is how many additional attempts are made after a failed
+	// attempt, with exponential backoff starting at RetryDelay (default
+	// 500ms).
+	Retries    int
+	RetryDelay time.Duration
+}
+
+// BroadcastResult is one target's outcome.
+type BroadcastResult struct {
+	Target   string `json:"target"`
+	Response string `json:"response,omitempty"`
+	Error    string `json:"error,omitempty"`
+	Attempts int    `json:"attempts"`
+}
+
+// Broadcast runs command against every target concurrently, bounded by
+// opts.Concurrency, retrying failed attempts with backoff. It returns one
+// BroadcastResult per target, in the same order as targets.
+func Broadcast(ctx context.Context, targets []Target, command string, opts BroadcastOptions) []BroadcastResult {
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = len(targets)
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Second
+	}
+
+	results := make([]BroadcastResult, len(targets))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, target := range targets {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, target Target) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = broadcastOne(ctx, target, command, opts)
+		}(i, target)
+	}
+	wg.Wait()
+
+	return results
+}
+
+func broadcastOne(ctx context.Context, target Target, command string, opts BroadcastOptions) BroadcastResult {
+	name := target.Name
+	if name == "" {
+		name = target.Addr
+	}
+
+	delay := opts.RetryDelay
+	if delay <= 0 {
+		delay = 500 * time.Millisecond
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= opts.Retries+1; attempt++ {
+		response, err := execOnTarget(ctx, target, command, opts.Timeout)
+		if err == nil {
+			return BroadcastResult{Target: name, Response: response, Attempts: attempt}
+		}
+		lastErr = err
+
+		if attempt > opts.Retries {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return BroadcastResult{Target: name, Error: ctx.Err().Error(), Attempts: attempt}
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+
+	return BroadcastResult{Target: name, Error: lastErr.Error(), Attempts: opts.Retries + 1}
+}
+
+func execOnTarget(ctx context.Context, target Target, command string, timeout time.Duration) (string, error) {
+	dialer := target.Dialer
+	if dialer == nil {
+		dialer = TCPDialer{}
+	}
+
+	dialCtx, cancel := context.WithTimeout(ctx, timeout)
+	client, err := DialWith(dialCtx, dialer, target.Addr)
+	cancel()
+	if err != nil {
+		return "", fmt.Errorf("dial: %w", err)
+	}
+	defer client.Close()
+
+	authCtx, cancel := context.WithTimeout(ctx, timeout)
+	err = client.Authenticate(authCtx, target.Password)
+	cancel()
+	if err != nil {
+		return "", fmt.Errorf("authenticate: %w", err)
+	}
+
+	execCtx, cancel := context.WithTimeout(ctx, timeout)
+	response, err := client.Exec(execCtx, command)
+	cancel()
+	if err != nil {
+		return "", fmt.Errorf("exec: %w", err)
+	}
+	return response, nil
+}
@@ -0,0 +1,191 @@
+package rcon
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// serveLoginAndExec runs a minimal fake RCON server for one login followed
+// by one Exec exchange, then closes the connection. It shares the frame
+// helpers from client_test.go.
+func serveLoginAndExec(t *testing.T, conn net.Conn, password, response string) {
+	t.Helper()
+	defer conn.Close()
+
+	fake := &Client{conn: conn, reader: bufio.NewReader(conn)}
+
+	login, err := fake.readFrame(context.Background())
+	if err != nil {
+		t.Errorf("fake server: read login packet: %v", err)
+		return
+	}
+	if login.payload != password {
+		writeFrameFragmented(t, conn, -1, commandType, "", 0)
+		return
+	}
+	writeFrameFragmented(t, conn, login.requestID, commandType, "", 0)
+
+	cmd, err := fake.readFrame(context.Background())
+	if err != nil {
+		t.Errorf("fake server: read command packet: %v", err)
+		return
+	}
+	sentinel, err := fake.readFrame(context.Background())
+	if err != nil {
+		t.Errorf("fake server: read sentinel packet: %v", err)
+		return
+	}
+
+	writeFrameFragmented(t, conn, cmd.requestID, responseType, response, 0)
+	writeFrameFragmented(t, conn, sentinel.requestID, responseType, "", 0)
+}
+
+// listenFake starts a fake RCON server listening on 127.0.0.1, invoking
+// accept for every incoming connection until the listener is closed.
+func listenFake(t *testing.T, accept func(net.Conn)) net.Listener {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go accept(conn)
+		}
+	}()
+	return ln
+}
+
+func TestBroadcastAggregatesResultsInOrder(t *testing.T) {
+	var targets []Target
+	var listeners []net.Listener
+	responses := []string{"server A response", "server B response", "server C response"}
+
+	for i, response := range responses {
+		response := response
+		ln := listenFake(t, func(conn net.Conn) {
+			serveLoginAndExec(t, conn, "secret", response)
+		})
+		listeners = append(listeners, ln)
+		targets = append(targets, Target{Name: string(rune('A' + i)), Addr: ln.Addr().String(), Password: "secret"})
+	}
+	defer func() {
+		for _, ln := range listeners {
+			ln.Close()
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	results := Broadcast(ctx, targets, "say hi", BroadcastOptions{})
+
+	if len(results) != len(targets) {
+		t.Fatalf("got %d results, want %d", len(results), len(targets))
+	}
+	for i, result := range results {
+		if result.Error != "" {
+			t.Errorf("target %d: unexpected error: %s", i, result.Error)
+		}
+		if result.Target != targets[i].Name {
+			t.Errorf("result %d out of order: got target %q, want %q", i, result.Target, targets[i].Name)
+		}
+		if result.Response != responses[i] {
+			t.Errorf("result %d: response = %q, want %q", i, result.Response, responses[i])
+		}
+	}
+}
+
+func TestBroadcastRetriesOnFailure(t *testing.T) {
+	var attempt int32
+	ln := listenFake(t, func(conn net.Conn) {
+		if atomic.AddInt32(&attempt, 1) == 1 {
+			conn.Close() // first attempt: drop the connection before replying
+			return
+		}
+		serveLoginAndExec(t, conn, "secret", "eventually ok")
+	})
+	defer ln.Close()
+
+	target := Target{Name: "flaky", Addr: ln.Addr().String(), Password: "secret"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	results := Broadcast(ctx, []Target{target}, "say hi", BroadcastOptions{
+		Retries:    2,
+		RetryDelay: 10 * time.Millisecond,
+	})
+
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1", len(results))
+	}
+	result := results[0]
+	if result.Error != "" {
+		t.Fatalf("unexpected error after retry: %s", result.Error)
+	}
+	if result.Response != "eventually ok" {
+		t.Errorf("response = %q, want %q", result.Response, "eventually ok")
+	}
+	if result.Attempts != 2 {
+		t.Errorf("attempts = %d, want 2", result.Attempts)
+	}
+}
+
+func TestBroadcastRespectsConcurrencyLimit(t *testing.T) {
+	const concurrency = 2
+	const targetCount = 6
+
+	var mu sync.Mutex
+	var current, peak int
+
+	var targets []Target
+	var listeners []net.Listener
+	for i := 0; i < targetCount; i++ {
+		ln := listenFake(t, func(conn net.Conn) {
+			mu.Lock()
+			current++
+			if current > peak {
+				peak = current
+			}
+			mu.Unlock()
+
+			time.Sleep(20 * time.Millisecond)
+			serveLoginAndExec(t, conn, "secret", "ok")
+
+			mu.Lock()
+			current--
+			mu.Unlock()
+		})
+		listeners = append(listeners, ln)
+		targets = append(targets, Target{Addr: ln.Addr().String(), Password: "secret"})
+	}
+	defer func() {
+		for _, ln := range listeners {
+			ln.Close()
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	results := Broadcast(ctx, targets, "say hi", BroadcastOptions{Concurrency: concurrency})
+
+	for i, result := range results {
+		if result.Error != "" {
+			t.Errorf("target %d: unexpected error: %s", i, result.Error)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if peak > concurrency {
+		t.Errorf("peak concurrent connections = %d, want <= %d", peak, concurrency)
+	}
+}
@@ -0,0 +1,179 @@
+// Package rcon implements a client for Valve's Source RCON protocol, as used
+// by Minecraft servers for remote console access.
+package rcon
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"strings"
+	"time"
+)
+
+// Client is a connection to an RCON server. It is not safe for concurrent
+// use: commands share a single response stream.
+type Client struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	seq    int32
+}
+
+// Dial connects to the RCON server at addr (host:port) over plain TCP. The
+// connection is not authenticated until Authenticate is called. To connect
+// over TLS or an SSH tunnel, use DialWith.
+func Dial(ctx context.Context, addr string) (*Client, error) {
+	return DialWith(ctx, TCPDialer{}, addr)
+}
+
+// DialWith connects to the RCON server at addr using dialer. The connection
+// is not authenticated until Authenticate is called.
+func DialWith(ctx context.Context, dialer Dialer, addr string) (*Client, error) {
+	conn, err := dialer.Dial(ctx, addr)
+	if err != nil {
+		return nil, wrapTimeout(err)
+	}
+
+	return &Client{
+		conn:   conn,
+		reader: bufio.NewReader(conn),
+		seq:    rand.Int31(),
+	}, nil
+}
+
+// Authenticate logs in with password. It must be called once, immediately
+// after Dial, before any call to Exec.
+func (c *Client) Authenticate(ctx context.Context, password string) error {
+	id := c.nextRequestID()
+	if err := c.sendPacket(ctx, id, loginType, password); err != nil {
+		return err
+	}
+
+	response, err := c.readFrame(ctx)
+	if err != nil {
+		return err
+	}
+
+	if response.requestID == -1 {
+		return ErrAuthFailed
+	}
+	if response.requestID != id || response.packetType != commandType {
+		return fmt.Errorf("rcon: unexpected login response")
+	}
+	return nil
+}
+
+// Exec sends command and returns the server's response, reassembling it from
+// as many packets as the server splits it across.
+func (c *Client) Exec(ctx context.Context, command string) (string, error) {
+	id := c.nextRequestID()
+	if err := c.sendPacket(ctx, id, commandType, command); err != nil {
+		return "", err
+	}
+
+	// The protocol has no length field for a command's total response, so a
+	// response spanning multiple packets has no explicit terminator. We work
+	// around this the way the Source RCON wiki recommends: immediately send
+	// a second, bogus packet behind the command. The server can't process it
+	// as a real request, so its reply (either a mirror of our bogus request
+	// ID, or an "Unknown request id" error with ID -1) is guaranteed to
+	// arrive only after every fragment of the real response has been sent.
+	sentinelID := c.nextRequestID()
+	if err := c.sendPacket(ctx, sentinelID, responseType, ""); err != nil {
+		return "", err
+	}
+
+	var payload strings.Builder
+	for {
+		frame, err := c.readFrame(ctx)
+		if err != nil {
+			return "", err
+		}
+		if frame.requestID == -1 || frame.requestID == sentinelID {
+			break
+		}
+		if frame.requestID != id {
+			return "", ErrFragmented
+		}
+		payload.WriteString(frame.payload)
+	}
+	return payload.String(), nil
+}
+
+// Close closes the underlying connection.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+func (c *Client) nextRequestID() int32 {
+	c.seq++
+	return c.seq
+}
+
+func (c *Client) sendPacket(ctx context.Context, requestID, packetType int32, payload string) error {
+	packet := rconPacket{
+		requestID:  requestID,
+		packetType: packetType,
+		payload:    payload,
+	}
+
+	c.setWriteDeadline(ctx)
+	_, err := c.conn.Write(packet.serialize())
+	if err != nil {
+		return wrapTimeout(err)
+	}
+	return nil
+}
+
+// readFrame reads exactly one packet off the wire: a 4-byte little-endian
+// length prefix followed by that many bytes. Reading the declared length
+// (rather than treating a short read as end-of-response) is what lets a
+// response be split across an arbitrary number of TCP reads without losing
+// or misinterpreting data.
+func (c *Client) readFrame(ctx context.Context) (rconPacket, error) {
+	c.setReadDeadline(ctx)
+
+	var size int32
+	if err := binary.Read(c.reader, binary.LittleEndian, &size); err != nil {
+		return rconPacket{}, wrapTimeout(err)
+	}
+	if size < 10 || size > maxPacketSize {
+		return rconPacket{}, fmt.Errorf("rcon: invalid packet size %d", size)
+	}
+
+	frame := make([]byte, 4+size)
+	binary.LittleEndian.PutUint32(frame[:4], uint32(size))
+	if _, err := io.ReadFull(c.reader, frame[4:]); err != nil {
+		return rconPacket{}, wrapTimeout(err)
+	}
+
+	return parsePacket(frame), nil
+}
+
+func (c *Client) setWriteDeadline(ctx context.Context) {
+	if deadline, ok := ctx.Deadline(); ok {
+		c.conn.SetWriteDeadline(deadline)
+	} else {
+		c.conn.SetWriteDeadline(time.Time{})
+	}
+}
+
+func (c *Client) setReadDeadline(ctx context.Context) {
+	if deadline, ok := ctx.Deadline(); ok {
+		c.conn.SetReadDeadline(deadline)
+	} else {
+		c.conn.SetReadDeadline(time.Time{})
+	}
+}
+
+func wrapTimeout(err error) error {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return fmt.Errorf("%w: %v", ErrTimeout, err)
+	}
+	return err
+}
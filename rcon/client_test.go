@@ -0,0 +1,126 @@
+package rcon
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+// writeFrameFragmented writes a serialized rconPacket to w in chunkSize-byte
+// writes, independent of the packet's own boundaries, to simulate a TCP
+// stream that fragments at arbitrary points.
+func writeFrameFragmented(t *testing.T, w net.Conn, requestID, packetType int32, payload string, chunkSize int) {
+	t.Helper()
+	packet := rconPacket{requestID: requestID, packetType: packetType, payload: payload}
+	data := packet.serialize()
+	if chunkSize <= 0 {
+		chunkSize = len(data)
+	}
+	for len(data) > 0 {
+		n := chunkSize
+		if n > len(data) {
+			n = len(data)
+		}
+		if _, err := w.Write(data[:n]); err != nil {
+			t.Fatalf("fake server: write: %v", err)
+		}
+		data = data[n:]
+	}
+}
+
+// serveOneExec runs a minimal fake RCON server for a single Exec exchange:
+// it reads the command packet and the sentinel packet, then replies with
+// the response split across chunks, followed by a mirrored sentinel reply.
+func serveOneExec(t *testing.T, conn net.Conn, chunks []string, chunkSize int) {
+	t.Helper()
+	defer conn.Close()
+
+	// A single shared Client (and thus bufio.Reader) is reused for both
+	// reads below, mirroring how the real client reads its own responses.
+	// A fresh bufio.Reader per read would silently drop any bytes it
+	// pre-buffered past the frame it was asked for.
+	fake := &Client{conn: conn, reader: bufio.NewReader(conn)}
+
+	cmd, err := fake.readFrame(context.Background())
+	if err != nil {
+		t.Errorf("fake server: read command packet: %v", err)
+		return
+	}
+	sentinel, err := fake.readFrame(context.Background())
+	if err != nil {
+		t.Errorf("fake server: read sentinel packet: %v", err)
+		return
+	}
+
+	for _, chunk := range chunks {
+		writeFrameFragmented(t, conn, cmd.requestID, responseType, chunk, chunkSize)
+	}
+	writeFrameFragmented(t, conn, sentinel.requestID, responseType, "", chunkSize)
+}
+
+func dialFake(t *testing.T) (*Client, net.Listener) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	client, err := Dial(ctx, ln.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	return client, ln
+}
+
+func TestExecReassemblesFragmentedResponse(t *testing.T) {
+	cases := []struct {
+		name      string
+		chunks    []string
+		chunkSize int
+	}{
+		{"single packet, whole write", []string{"Hello, World!"}, 0},
+		{"single packet, byte-at-a-time TCP writes", []string{"Hello, World!"}, 1},
+		{"multi packet response", []string{"part one ", "part two ", "part three"}, 0},
+		{"multi packet response, fragmented TCP writes", []string{"part one ", "part two ", "part three"}, 3},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			client, ln := dialFake(t)
+			defer ln.Close()
+			defer client.Close()
+
+			serverDone := make(chan struct{})
+			go func() {
+				defer close(serverDone)
+				conn, err := ln.Accept()
+				if err != nil {
+					t.Errorf("accept: %v", err)
+					return
+				}
+				serveOneExec(t, conn, tc.chunks, tc.chunkSize)
+			}()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			response, err := client.Exec(ctx, "test command")
+			if err != nil {
+				t.Fatalf("Exec: %v", err)
+			}
+
+			var want string
+			for _, chunk := range tc.chunks {
+				want += chunk
+			}
+			if response != want {
+				t.Errorf("response = %q, want %q", response, want)
+			}
+
+			<-serverDone
+		})
+	}
+}
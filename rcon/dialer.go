@@ -0,0 +1,103 @@
+package rcon
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Dialer establishes the transport connection a Client is built on top of.
+// Implementations let a Client be reached over plain TCP, TLS, or an SSH
+// tunnel without the protocol layer needing to know which.
+type Dialer interface {
+	Dial(ctx context.Context, addr string) (net.Conn, error)
+}
+
+// TCPDialer dials addr directly over TCP. It is the default transport.
+type TCPDialer struct{}
+
+// Dial implements Dialer.
+func (TCPDialer) Dial(ctx context.Context, addr string) (net.Conn, error) {
+	var d net.Dialer
+	return d.DialContext(ctx, "tcp", addr)
+}
+
+// TLSDialer dials addr over TCP and wraps the connection in TLS. A nil
+// Config dials with Go's default TLS settings.
+type TLSDialer struct {
+	Config *tls.Config
+}
+
+// Dial implements Dialer.
+func (d TLSDialer) Dial(ctx context.Context, addr string) (net.Conn, error) {
+	dialer := tls.Dialer{Config: d.Config}
+	return dialer.DialContext(ctx, "tcp", addr)
+}
+
+// SSHDialer reaches addr by first dialing SSHAddr and then asking that SSH
+// server to open a TCP connection to addr on its end, i.e. a local-forward
+// tunnel. This is how an RCON port that's only bound to localhost on the
+// game server can be reached without exposing it directly.
+type SSHDialer struct {
+	// SSHAddr is the SSH server to tunnel through, as host:port.
+	SSHAddr string
+	Config  *ssh.ClientConfig
+}
+
+// Dial implements Dialer.
+func (d SSHDialer) Dial(ctx context.Context, addr string) (net.Conn, error) {
+	var netDialer net.Dialer
+	sshConn, err := netDialer.DialContext(ctx, "tcp", d.SSHAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	clientConn, chans, reqs, err := ssh.NewClientConn(sshConn, d.SSHAddr, d.Config)
+	if err != nil {
+		sshConn.Close()
+		return nil, err
+	}
+	client := ssh.NewClient(clientConn, chans, reqs)
+
+	type result struct {
+		conn net.Conn
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		conn, err := client.Dial("tcp", addr)
+		done <- result{conn, err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		client.Close()
+		return nil, ctx.Err()
+	case r := <-done:
+		if r.err != nil {
+			client.Close()
+			return nil, r.err
+		}
+		return &sshTunnelConn{Conn: r.conn, client: client}, nil
+	}
+}
+
+// sshTunnelConn is a forwarded channel paired with the *ssh.Client that
+// opened it. Closing just the channel would leave the underlying SSH
+// transport connection and its background goroutines running, so Close
+// tears down both.
+type sshTunnelConn struct {
+	net.Conn
+	client *ssh.Client
+}
+
+func (c *sshTunnelConn) Close() error {
+	connErr := c.Conn.Close()
+	clientErr := c.client.Close()
+	if connErr != nil {
+		return connErr
+	}
+	return clientErr
+}
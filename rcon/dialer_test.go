@@ -0,0 +1,120 @@
+package rcon
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+)
+
+// generateTestCert builds a short-lived, self-signed certificate valid for
+// the IP 127.0.0.1, so a client can verify it via RootCAs without
+// InsecureSkipVerify.
+func generateTestCert(t *testing.T) tls.Certificate {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse certificate: %v", err)
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+func TestTLSDialerConnects(t *testing.T) {
+	cert := generateTestCert(t)
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		serveLoginAndExec(t, conn, "secret", "tls works")
+	}()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert.Leaf)
+	dialer := TLSDialer{Config: &tls.Config{RootCAs: pool}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	client, err := DialWith(ctx, dialer, ln.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer client.Close()
+
+	authCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := client.Authenticate(authCtx, "secret"); err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+
+	execCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	response, err := client.Exec(execCtx, "say hi")
+	if err != nil {
+		t.Fatalf("exec: %v", err)
+	}
+	if response != "tls works" {
+		t.Errorf("response = %q, want %q", response, "tls works")
+	}
+}
+
+func TestTLSDialerRejectsUntrustedCert(t *testing.T) {
+	cert := generateTestCert(t)
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+
+	go func() {
+		conn, err := ln.Accept()
+		if err == nil {
+			conn.Close()
+		}
+	}()
+
+	// No RootCAs given, so the self-signed leaf isn't trusted and the
+	// handshake must fail rather than silently succeeding.
+	dialer := TLSDialer{Config: &tls.Config{}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if _, err := DialWith(ctx, dialer, ln.Addr().String()); err == nil {
+		t.Fatal("expected an error dialing an untrusted certificate, got none")
+	}
+}
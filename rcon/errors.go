@@ -0,0 +1,15 @@
+package rcon
+
+import "errors"
+
+// ErrAuthFailed is returned by Authenticate when the server rejects the
+// supplied password.
+var ErrAuthFailed = errors.New("rcon: authentication failed")
+
+// ErrTimeout is returned when a dial, authenticate, or exec call exceeds its
+// context deadline.
+var ErrTimeout = errors.New("rcon: timed out")
+
+// ErrFragmented is returned when a response could not be reassembled from
+// its constituent packets.
+var ErrFragmented = errors.New("rcon: response could not be reassembled")
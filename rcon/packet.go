@@ -0,0 +1,59 @@
+package rcon
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+const (
+	// responseType is for responses to commands
+	responseType = 0
+	// commandType is for sending commands and receiving a login response
+	commandType = 2
+	// loginType is for sending login requests
+	loginType = 3
+)
+
+// maxPacketSize is the largest size a single packet's length prefix may
+// declare: requestID + packetType + a 4096-byte payload + 2 bytes padding.
+const maxPacketSize = 4 + 4 + 4096 + 2
+
+type rconPacket struct {
+	requestID  int32
+	packetType int32
+	payload    string
+}
+
+func (rp *rconPacket) serialize() []byte {
+
+	// we keep the first 4 bytes for the packet size
+	packet := bytes.NewBuffer([]byte{})
+
+	// size = requestID + packetType + payload + padding
+	packetSize := int32(4 + 4 + len(rp.payload) + 2)
+
+	// integers are little endian, opposite of Minecraft protocol
+	binary.Write(packet, binary.LittleEndian, packetSize)
+	binary.Write(packet, binary.LittleEndian, rp.requestID)
+	binary.Write(packet, binary.LittleEndian, rp.packetType)
+	binary.Write(packet, binary.LittleEndian, []byte(rp.payload))
+
+	// two bytes of padding at the end
+	packet.Write([]byte{0, 0})
+
+	return packet.Bytes()
+}
+
+func parsePacket(data []byte) rconPacket {
+	var packetSize int32
+	reader := bytes.NewReader(data)
+	packet := rconPacket{}
+	binary.Read(reader, binary.LittleEndian, &packetSize)
+	binary.Read(reader, binary.LittleEndian, &packet.requestID)
+	binary.Read(reader, binary.LittleEndian, &packet.packetType)
+
+	// packetSize is actual size-4, so end-2 is packetSize+2
+	packet.payload = string(data[12 : packetSize+2])
+
+	return packet
+}
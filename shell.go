@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/chzyer/readline"
+
+	"github.com/malcolmseyd/mc-rcon/rcon"
+)
+
+// shellConfig holds the mutable state of an interactive session: the
+// connection itself (swappable via /reconnect), the response timeout and
+// color settings (changeable via /timeout and /color), and how to build a
+// fresh, authenticated connection when asked to reconnect.
+type shellConfig struct {
+	ctx       context.Context
+	client    *rcon.Client
+	colored   bool
+	timeout   time.Duration
+	reconnect func(ctx context.Context) (*rcon.Client, error)
+}
+
+// historyFile returns the path readline should persist history to.
+func historyFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".mc_rcon_history")
+}
+
+// interactiveMode runs a readline-backed shell against cfg.client until the
+// user quits (Ctrl-D, Ctrl-C on an empty line, or /quit). It returns nil on
+// a clean exit.
+func interactiveMode(cfg *shellConfig) error {
+	discoveryCtx, cancelDiscovery := context.WithCancel(cfg.ctx)
+	defer cancelDiscovery()
+
+	discovery := newCommandDiscovery(cfg.client)
+	discovery.RefreshCommands(discoveryCtx)
+	go discovery.Run(discoveryCtx)
+
+	rl, err := readline.NewEx(&readline.Config{
+		Prompt:          "> ",
+		HistoryFile:     historyFile(),
+		AutoComplete:    &shellCompleter{discovery: discovery},
+		InterruptPrompt: "^C",
+		EOFPrompt:       "^D",
+	})
+	if err != nil {
+		return err
+	}
+	defer rl.Close()
+
+	var transcript *os.File
+	defer func() {
+		if transcript != nil {
+			transcript.Close()
+		}
+	}()
+
+	for {
+		line, err := rl.Readline()
+		if err == readline.ErrInterrupt {
+			if len(line) == 0 {
+				return nil
+			}
+			continue
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "/") {
+			quit, err := handleMeta(cfg, discovery, line, &transcript)
+			if err != nil {
+				errorln(err)
+			}
+			if quit {
+				return nil
+			}
+			continue
+		}
+
+		execCtx, cancel := context.WithTimeout(cfg.ctx, cfg.timeout)
+		response, err := discovery.Exec(execCtx, line)
+		cancel()
+		if err != nil {
+			errorln(err)
+			continue
+		}
+
+		colorPrint(response, cfg.colored)
+		if transcript != nil {
+			fmt.Fprintf(transcript, "> %s\n%s\n", line, response)
+		}
+	}
+}
+
+// handleMeta parses and runs a slash-prefixed local meta-command; these are
+// handled entirely client-side and never reach the server.
+func handleMeta(cfg *shellConfig, discovery *commandDiscovery, line string, transcript **os.File) (quit bool, err error) {
+	fields := strings.Fields(line)
+	switch fields[0] {
+	case "/quit":
+		return true, nil
+
+	case "/reconnect":
+		client, err := cfg.reconnect(cfg.ctx)
+		if err != nil {
+			return false, fmt.Errorf("reconnect: %w", err)
+		}
+		cfg.client.Close()
+		cfg.client = client
+		discovery.SetClient(client)
+		discovery.RefreshCommands(cfg.ctx)
+		fmt.Println("Reconnected")
+		return false, nil
+
+	case "/timeout":
+		if len(fields) != 2 {
+			return false, fmt.Errorf("usage: /timeout <duration>")
+		}
+		d, err := time.ParseDuration(fields[1])
+		if err != nil {
+			return false, fmt.Errorf("invalid duration %q: %w", fields[1], err)
+		}
+		cfg.timeout = d
+		return false, nil
+
+	case "/color":
+		if len(fields) != 2 || (fields[1] != "on" && fields[1] != "off") {
+			return false, fmt.Errorf("usage: /color on|off")
+		}
+		cfg.colored = fields[1] == "on"
+		return false, nil
+
+	case "/save":
+		if len(fields) != 2 {
+			return false, fmt.Errorf("usage: /save <file>")
+		}
+		f, err := os.Create(fields[1])
+		if err != nil {
+			return false, err
+		}
+		if *transcript != nil {
+			(*transcript).Close()
+		}
+		*transcript = f
+		fmt.Println("Saving transcript to", fields[1])
+		return false, nil
+
+	default:
+		return false, fmt.Errorf("unknown meta-command: %s", fields[0])
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"github.com/malcolmseyd/mc-rcon/rcon"
+)
+
+// transportFlags holds the --tls* and --ssh* flag values used to build a
+// rcon.Dialer.
+type transportFlags struct {
+	host string
+
+	tls         bool
+	tlsCA       string
+	tlsInsecure bool
+
+	ssh    string
+	sshKey string
+}
+
+func buildDialer(f transportFlags) (rcon.Dialer, error) {
+	if f.tls && f.ssh != "" {
+		return nil, fmt.Errorf("--tls and --ssh cannot be used together")
+	}
+
+	switch {
+	case f.ssh != "":
+		return buildSSHDialer(f.ssh, f.sshKey)
+	case f.tls:
+		return buildTLSDialer(f.host, f.tlsCA, f.tlsInsecure)
+	default:
+		return rcon.TCPDialer{}, nil
+	}
+}
+
+func buildTLSDialer(host, caFile string, insecure bool) (rcon.Dialer, error) {
+	config := &tls.Config{
+		ServerName:         host,
+		InsecureSkipVerify: insecure,
+	}
+
+	if caFile != "" {
+		pemBytes, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("read --tls-ca: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pemBytes) {
+			return nil, fmt.Errorf("no certificates found in %s", caFile)
+		}
+		config.RootCAs = pool
+	}
+
+	return rcon.TLSDialer{Config: config}, nil
+}
+
+func buildSSHDialer(target, keyFile string) (rcon.Dialer, error) {
+	user, addr, err := parseSSHTarget(target)
+	if err != nil {
+		return nil, err
+	}
+
+	auth, err := sshAuthMethod(keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	hostKeyCallback, err := sshHostKeyCallback()
+	if err != nil {
+		return nil, err
+	}
+
+	return rcon.SSHDialer{
+		SSHAddr: addr,
+		Config: &ssh.ClientConfig{
+			User:            user,
+			Auth:            []ssh.AuthMethod{auth},
+			HostKeyCallback: hostKeyCallback,
+			Timeout:         defaultTimeout,
+		},
+	}, nil
+}
+
+// parseSSHTarget splits "user@host[:port]" into its user and host:port.
+func parseSSHTarget(target string) (user, addr string, err error) {
+	user, hostport, ok := strings.Cut(target, "@")
+	if !ok {
+		return "", "", fmt.Errorf("--ssh target must be user@host[:port], got %q", target)
+	}
+	if _, _, err := net.SplitHostPort(hostport); err != nil {
+		hostport = net.JoinHostPort(hostport, "22")
+	}
+	return user, hostport, nil
+}
+
+// sshAuthMethod loads a private key from keyFile, falling back to the
+// running ssh-agent when keyFile is empty.
+func sshAuthMethod(keyFile string) (ssh.AuthMethod, error) {
+	if keyFile != "" {
+		keyBytes, err := os.ReadFile(keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("read --ssh-key: %w", err)
+		}
+		signer, err := ssh.ParsePrivateKey(keyBytes)
+		if err != nil {
+			return nil, fmt.Errorf("parse --ssh-key: %w", err)
+		}
+		return ssh.PublicKeys(signer), nil
+	}
+
+	sock := os.Getenv("SSH_AUTH_SOCK")
+	if sock == "" {
+		return nil, fmt.Errorf("--ssh-key not given and SSH_AUTH_SOCK is not set")
+	}
+	agentConn, err := net.Dial("unix", sock)
+	if err != nil {
+		return nil, fmt.Errorf("connect to ssh-agent: %w", err)
+	}
+	return ssh.PublicKeysCallback(agent.NewClient(agentConn).Signers), nil
+}
+
+func sshHostKeyCallback() (ssh.HostKeyCallback, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	return knownhosts.New(filepath.Join(home, ".ssh", "known_hosts"))
+}
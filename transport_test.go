@@ -0,0 +1,40 @@
+package main
+
+import "testing"
+
+func TestParseSSHTarget(t *testing.T) {
+	cases := []struct {
+		name     string
+		target   string
+		wantUser string
+		wantAddr string
+		wantErr  bool
+	}{
+		{"host and port given", "alice@example.com:2222", "alice", "example.com:2222", false},
+		{"host only, defaults to port 22", "alice@example.com", "alice", "example.com:22", false},
+		{"ipv6 host with port", "alice@[::1]:2222", "alice", "[::1]:2222", false},
+		{"ipv6 host, defaults to port 22", "alice@::1", "alice", "[::1]:22", false},
+		{"missing user", "example.com:2222", "", "", true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			user, addr, err := parseSSHTarget(tc.target)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected an error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if user != tc.wantUser {
+				t.Errorf("user = %q, want %q", user, tc.wantUser)
+			}
+			if addr != tc.wantAddr {
+				t.Errorf("addr = %q, want %q", addr, tc.wantAddr)
+			}
+		})
+	}
+}